@@ -0,0 +1,130 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types reported on a DaprTrustBundle's status.
+const (
+	// ConditionSynced is True once every target has been written with the
+	// current source data.
+	ConditionSynced = "Synced"
+	// ConditionReady is True once Synced is True and all targets exist.
+	ConditionReady = "Ready"
+)
+
+// SourceSecretKeySelector references a Secret holding the trust material to
+// distribute.
+type SourceSecretKeySelector struct {
+	// Name of the Secret.
+	Name string `json:"name"`
+	// Namespace of the Secret. Defaults to the DaprTrustBundle's namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Keys restricts which data keys are read from the Secret, e.g.
+	// ["tls.crt", "tls.key"] to distribute a certificate/key pair from one
+	// Secret. If empty, every key in the Secret is available to targets'
+	// keyMappings, matching the Certificate source's behavior.
+	// +optional
+	Keys []string `json:"keys,omitempty"`
+}
+
+// SourceCertificateRef references a cert-manager Certificate whose backing
+// Secret is used as the source of trust material.
+type SourceCertificateRef struct {
+	// Name of the Certificate.
+	Name string `json:"name"`
+	// Namespace of the Certificate. Defaults to the DaprTrustBundle's namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// DaprTrustBundleSource is the single source of trust material for a
+// DaprTrustBundle. Exactly one of SecretKey or Certificate must be set.
+type DaprTrustBundleSource struct {
+	// SecretKey reads the source data directly from a Secret key.
+	// +optional
+	SecretKey *SourceSecretKeySelector `json:"secretKey,omitempty"`
+	// Certificate reads the source data from a cert-manager Certificate's
+	// backing Secret.
+	// +optional
+	Certificate *SourceCertificateRef `json:"certificate,omitempty"`
+}
+
+// DaprTrustBundleTarget describes one destination that the source data is
+// fanned out to, across every namespace matched by NamespaceSelector.
+type DaprTrustBundleTarget struct {
+	// Kind of object to write, either "Secret" or "ConfigMap".
+	// +kubebuilder:validation:Enum=Secret;ConfigMap
+	Kind string `json:"kind"`
+	// Name of the target object created in each selected namespace.
+	Name string `json:"name"`
+	// NamespaceSelector selects the namespaces the target is written into.
+	// When unset, the target is written into the DaprTrustBundle's own
+	// namespace only; an empty selector ({}) matches every namespace.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// KeyMappings maps a source data key to the key it is written under in
+	// the target, e.g. {"tls.crt": "issuer.crt"}.
+	KeyMappings map[string]string `json:"keyMappings"`
+}
+
+// DaprTrustBundleSpec defines the desired state of a DaprTrustBundle.
+type DaprTrustBundleSpec struct {
+	// Source is the single origin of the trust material.
+	Source DaprTrustBundleSource `json:"source"`
+	// Targets lists every place the source material should be distributed to.
+	Targets []DaprTrustBundleTarget `json:"targets"`
+}
+
+// DaprTrustBundleTargetStatus reports the sync state of one fanned-out
+// target object.
+type DaprTrustBundleTargetStatus struct {
+	// Kind of the target object.
+	Kind string `json:"kind"`
+	// Namespace the target object was written into.
+	Namespace string `json:"namespace"`
+	// Name of the target object.
+	Name string `json:"name"`
+	// Hash of the data last written to the target. It is reported only; the
+	// reconciler does not read it back to skip writes.
+	Hash string `json:"hash"`
+}
+
+// DaprTrustBundleStatus reflects the observed state of a DaprTrustBundle.
+type DaprTrustBundleStatus struct {
+	// Conditions holds the latest observations, including "Synced" and "Ready".
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Targets reports the per-target sync state.
+	// +optional
+	Targets []DaprTrustBundleTargetStatus `json:"targets,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+
+// DaprTrustBundle declares one source of trust material and the set of
+// Secrets/ConfigMaps it should be distributed to, as an alternative to the
+// single-hardcoded-secret flow driven by the watch-secret-* flags.
+type DaprTrustBundle struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DaprTrustBundleSpec   `json:"spec,omitempty"`
+	Status DaprTrustBundleStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DaprTrustBundleList contains a list of DaprTrustBundle.
+type DaprTrustBundleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DaprTrustBundle `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DaprTrustBundle{}, &DaprTrustBundleList{})
+}
@@ -0,0 +1,345 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	daprv1alpha1 "github.com/safanaj/dapr-cert-transformer/api/v1alpha1"
+)
+
+// trustBundleOwnerNamespaceLabelKey and trustBundleOwnerNameLabelKey mark
+// every Secret/ConfigMap this reconciler fans data out to with the
+// DaprTrustBundle that owns it, so MapTargetToBundle can route change events
+// on that object back to a reconcile request. Owner references can't do this
+// for cross-namespace targets (see setOwnerRefIfSameNamespace), so these
+// labels are the correlation mechanism instead, applied to same-namespace
+// and cross-namespace targets alike.
+const (
+	trustBundleOwnerNamespaceLabelKey = "dapr-cert-transformer.safanaj.github.io/trust-bundle-namespace"
+	trustBundleOwnerNameLabelKey      = "dapr-cert-transformer.safanaj.github.io/trust-bundle-name"
+)
+
+// DaprTrustBundleReconciler reconciles a DaprTrustBundle object: it reads the
+// data named by spec.source and fans it out, under the per-target key
+// mappings, to every Secret/ConfigMap matched by spec.targets.
+//
+// +kubebuilder:rbac:groups=dapr.io,resources=daprtrustbundles,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dapr.io,resources=daprtrustbundles/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets;configmaps;namespaces,verbs=get;list;watch;create;update;patch
+type DaprTrustBundleReconciler struct {
+	client.Client
+}
+
+// SetupWithManager is left to the caller (main.go), which builds the
+// For/Watches wiring for this reconciler inline.
+
+// MapTargetToBundle maps a fanned-out Secret/ConfigMap event to the
+// reconcile request for the DaprTrustBundle that owns it, read off the
+// trustBundleOwner* labels set by applyTarget. It is registered on the
+// Secret/ConfigMap watches so a target edited or deleted out of band is
+// self-healed instead of drifting until the DaprTrustBundle itself changes.
+func MapTargetToBundle(_ context.Context, o client.Object) []reconcile.Request {
+	namespace, ok := o.GetLabels()[trustBundleOwnerNamespaceLabelKey]
+	if !ok {
+		return nil
+	}
+	name, ok := o.GetLabels()[trustBundleOwnerNameLabelKey]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}}
+}
+
+// MapNamespaceToBundles maps a Namespace event to every DaprTrustBundle that
+// has at least one target with a namespaceSelector, since any such bundle
+// might now match (or no longer match) the namespace. Without this, a
+// namespace that starts matching a selector after the DaprTrustBundle was
+// created would never receive its targets until the bundle's own spec was
+// touched again.
+func (r *DaprTrustBundleReconciler) MapNamespaceToBundles(ctx context.Context, _ client.Object) []reconcile.Request {
+	bundleList := &daprv1alpha1.DaprTrustBundleList{}
+	if err := r.List(ctx, bundleList); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list DaprTrustBundles for Namespace event")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, bundle := range bundleList.Items {
+		for _, target := range bundle.Spec.Targets {
+			if target.NamespaceSelector != nil {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name}})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+func (r *DaprTrustBundleReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	l := logf.FromContext(ctx)
+
+	bundle := &daprv1alpha1.DaprTrustBundle{}
+	if err := r.Get(ctx, req.NamespacedName, bundle); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		l.Error(err, "Failed to get DaprTrustBundle")
+		return reconcile.Result{}, err
+	}
+
+	prevTargets := bundle.Status.Targets
+
+	data, err := r.resolveSource(ctx, bundle)
+	if err != nil {
+		l.Error(err, "Failed to resolve source")
+		r.setCondition(bundle, daprv1alpha1.ConditionSynced, metav1.ConditionFalse, "SourceUnresolved", err.Error())
+		r.setCondition(bundle, daprv1alpha1.ConditionReady, metav1.ConditionFalse, "SourceUnresolved", err.Error())
+		return reconcile.Result{}, r.Status().Update(ctx, bundle)
+	}
+
+	var targetStatuses []daprv1alpha1.DaprTrustBundleTargetStatus
+	for _, target := range bundle.Spec.Targets {
+		namespaces, err := r.matchingNamespaces(ctx, target.NamespaceSelector, bundle.Namespace)
+		if err != nil {
+			l.Error(err, "Failed to resolve target namespaces", "target", target.Name)
+			return reconcile.Result{}, err
+		}
+
+		for _, ns := range namespaces {
+			hash, err := r.applyTarget(ctx, bundle, target, ns, data)
+			if err != nil {
+				l.Error(err, "Failed to apply target", "target", target.Name, "namespace", ns)
+				r.setCondition(bundle, daprv1alpha1.ConditionSynced, metav1.ConditionFalse, "TargetWriteFailed", err.Error())
+				r.setCondition(bundle, daprv1alpha1.ConditionReady, metav1.ConditionFalse, "TargetWriteFailed", err.Error())
+				return reconcile.Result{}, errors.Join(err, r.Status().Update(ctx, bundle))
+			}
+			targetStatuses = append(targetStatuses, daprv1alpha1.DaprTrustBundleTargetStatus{
+				Kind:      target.Kind,
+				Namespace: ns,
+				Name:      target.Name,
+				Hash:      hash,
+			})
+		}
+	}
+
+	if err := r.deleteStaleTargets(ctx, prevTargets, targetStatuses); err != nil {
+		l.Error(err, "Failed to delete stale targets")
+		r.setCondition(bundle, daprv1alpha1.ConditionSynced, metav1.ConditionFalse, "StaleTargetDeleteFailed", err.Error())
+		r.setCondition(bundle, daprv1alpha1.ConditionReady, metav1.ConditionFalse, "StaleTargetDeleteFailed", err.Error())
+		return reconcile.Result{}, errors.Join(err, r.Status().Update(ctx, bundle))
+	}
+
+	bundle.Status.Targets = targetStatuses
+	r.setCondition(bundle, daprv1alpha1.ConditionSynced, metav1.ConditionTrue, "Synced", "All targets are up-to-date")
+	r.setCondition(bundle, daprv1alpha1.ConditionReady, metav1.ConditionTrue, "Ready", "Bundle is synced to all targets")
+
+	if err := r.Status().Update(ctx, bundle); err != nil {
+		l.Error(err, "Failed to update DaprTrustBundle status")
+		return reconcile.Result{}, err
+	}
+
+	l.Info("Successful reconciliation", "targets", len(targetStatuses))
+	return reconcile.Result{}, nil
+}
+
+// resolveSource reads the bundle's single source (a Secret key or a
+// cert-manager Certificate's backing Secret) into a plain key/value map.
+func (r *DaprTrustBundleReconciler) resolveSource(ctx context.Context, bundle *daprv1alpha1.DaprTrustBundle) (map[string][]byte, error) {
+	src := bundle.Spec.Source
+	switch {
+	case src.SecretKey != nil:
+		ns := src.SecretKey.Namespace
+		if ns == "" {
+			ns = bundle.Namespace
+		}
+		s := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: ns, Name: src.SecretKey.Name}, s); err != nil {
+			return nil, fmt.Errorf("could not get source Secret %s/%s: %w", ns, src.SecretKey.Name, err)
+		}
+		if len(src.SecretKey.Keys) == 0 {
+			return s.Data, nil
+		}
+		data := make(map[string][]byte, len(src.SecretKey.Keys))
+		for _, key := range src.SecretKey.Keys {
+			v, ok := s.Data[key]
+			if !ok {
+				return nil, fmt.Errorf("source Secret %s/%s has no key %q", ns, src.SecretKey.Name, key)
+			}
+			data[key] = v
+		}
+		return data, nil
+	case src.Certificate != nil:
+		ns := src.Certificate.Namespace
+		if ns == "" {
+			ns = bundle.Namespace
+		}
+		// cert-manager Certificates store their issued material in a Secret
+		// of the same name as the Certificate.
+		s := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: ns, Name: src.Certificate.Name}, s); err != nil {
+			return nil, fmt.Errorf("could not get backing Secret for Certificate %s/%s: %w", ns, src.Certificate.Name, err)
+		}
+		return s.Data, nil
+	default:
+		return nil, fmt.Errorf("spec.source must set either secretKey or certificate")
+	}
+}
+
+// matchingNamespaces returns the names of every namespace matched by
+// selector, or just bundleNamespace when selector is nil.
+func (r *DaprTrustBundleReconciler) matchingNamespaces(ctx context.Context, selector *metav1.LabelSelector, bundleNamespace string) ([]string, error) {
+	if selector == nil {
+		return []string{bundleNamespace}, nil
+	}
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespaceSelector: %w", err)
+	}
+	nsList := &corev1.NamespaceList{}
+	if err := r.List(ctx, nsList, &client.ListOptions{LabelSelector: sel}); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(nsList.Items))
+	for _, ns := range nsList.Items {
+		names = append(names, ns.Name)
+	}
+	return names, nil
+}
+
+// applyTarget creates or updates the single target object for one
+// (target, namespace) pair and returns a hash of the data it now holds.
+func (r *DaprTrustBundleReconciler) applyTarget(ctx context.Context, bundle *daprv1alpha1.DaprTrustBundle, target daprv1alpha1.DaprTrustBundleTarget, namespace string, source map[string][]byte) (string, error) {
+	remapped := make(map[string][]byte, len(target.KeyMappings))
+	for srcKey, destKey := range target.KeyMappings {
+		v, ok := source[srcKey]
+		if !ok {
+			return "", fmt.Errorf("source has no key %q required by target %q", srcKey, target.Name)
+		}
+		remapped[destKey] = v
+	}
+	hash := hashData(remapped)
+
+	switch target.Kind {
+	case "Secret":
+		obj := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: target.Name, Namespace: namespace}}
+		_, err := controllerutil.CreateOrUpdate(ctx, r.Client, obj, func() error {
+			obj.Data = remapped
+			setTrustBundleOwnerLabels(obj, bundle)
+			return r.setOwnerRefIfSameNamespace(bundle, obj, namespace)
+		})
+		return hash, err
+	case "ConfigMap":
+		obj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: target.Name, Namespace: namespace}}
+		_, err := controllerutil.CreateOrUpdate(ctx, r.Client, obj, func() error {
+			obj.BinaryData = remapped
+			setTrustBundleOwnerLabels(obj, bundle)
+			return r.setOwnerRefIfSameNamespace(bundle, obj, namespace)
+		})
+		return hash, err
+	default:
+		return "", fmt.Errorf("unsupported target kind %q", target.Kind)
+	}
+}
+
+// setTrustBundleOwnerLabels stamps obj with the labels MapTargetToBundle
+// reads back to route its change events to bundle's reconcile request.
+func setTrustBundleOwnerLabels(obj client.Object, bundle *daprv1alpha1.DaprTrustBundle) {
+	l := obj.GetLabels()
+	if l == nil {
+		l = map[string]string{}
+	}
+	l[trustBundleOwnerNamespaceLabelKey] = bundle.Namespace
+	l[trustBundleOwnerNameLabelKey] = bundle.Name
+	obj.SetLabels(l)
+}
+
+// deleteStaleTargets removes every target present in prevTargets but absent
+// from currentTargets. Owner references only garbage-collect same-namespace
+// targets (see setOwnerRefIfSameNamespace), so this is what retires a target
+// dropped from spec.targets, or a namespace that a namespaceSelector no
+// longer matches, when it lives in a different namespace than the bundle.
+func (r *DaprTrustBundleReconciler) deleteStaleTargets(ctx context.Context, prevTargets, currentTargets []daprv1alpha1.DaprTrustBundleTargetStatus) error {
+	current := make(map[daprv1alpha1.DaprTrustBundleTargetStatus]bool, len(currentTargets))
+	for _, t := range currentTargets {
+		current[targetKey(t)] = true
+	}
+
+	for _, t := range prevTargets {
+		if current[targetKey(t)] {
+			continue
+		}
+
+		var obj client.Object
+		switch t.Kind {
+		case "Secret":
+			obj = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: t.Name, Namespace: t.Namespace}}
+		case "ConfigMap":
+			obj = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: t.Name, Namespace: t.Namespace}}
+		default:
+			return fmt.Errorf("unsupported stale target kind %q", t.Kind)
+		}
+
+		if err := r.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("could not delete stale target %s %s/%s: %w", t.Kind, t.Namespace, t.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// targetKey returns the subset of a DaprTrustBundleTargetStatus that
+// identifies the object it points at, for use as a set/map key that ignores
+// content changes (Hash).
+func targetKey(t daprv1alpha1.DaprTrustBundleTargetStatus) daprv1alpha1.DaprTrustBundleTargetStatus {
+	t.Hash = ""
+	return t
+}
+
+// setOwnerRefIfSameNamespace sets an owner reference for garbage collection.
+// Owner references are namespace-scoped in Kubernetes, so targets fanned out
+// to a different namespace than the DaprTrustBundle are tracked via
+// status.targets instead and are not automatically garbage-collected.
+func (r *DaprTrustBundleReconciler) setOwnerRefIfSameNamespace(bundle *daprv1alpha1.DaprTrustBundle, obj client.Object, namespace string) error {
+	if namespace != bundle.Namespace {
+		return nil
+	}
+	return controllerutil.SetOwnerReference(bundle, obj, r.Scheme())
+}
+
+func (r *DaprTrustBundleReconciler) setCondition(bundle *daprv1alpha1.DaprTrustBundle, condType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&bundle.Status.Conditions, metav1.Condition{
+		Type:               condType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: bundle.Generation,
+	})
+}
+
+func hashData(data map[string][]byte) string {
+	h := sha256.New()
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write(data[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
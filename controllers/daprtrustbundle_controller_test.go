@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	daprv1alpha1 "github.com/safanaj/dapr-cert-transformer/api/v1alpha1"
+)
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("could not register client-go scheme: %v", err)
+	}
+	if err := daprv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("could not register dapr.io/v1alpha1 scheme: %v", err)
+	}
+	return scheme
+}
+
+// TestReconcileMultiKeySecretSource exercises a target with a multi-key
+// keyMappings (tls.crt + tls.key) against a secretKey source, the exact
+// shape shipped in config/samples/dapr_v1alpha1_daprtrustbundle.yaml.
+func TestReconcileMultiKeySecretSource(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	sourceSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "dapr-trust-bundle", Namespace: "default"},
+		Data: map[string][]byte{
+			"tls.crt": []byte("cert-bytes"),
+			"tls.key": []byte("key-bytes"),
+		},
+	}
+
+	bundle := &daprv1alpha1.DaprTrustBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: "dapr-trust-bundle", Namespace: "default"},
+		Spec: daprv1alpha1.DaprTrustBundleSpec{
+			Source: daprv1alpha1.DaprTrustBundleSource{
+				SecretKey: &daprv1alpha1.SourceSecretKeySelector{
+					Name: "dapr-trust-bundle",
+					Keys: []string{"tls.crt", "tls.key"},
+				},
+			},
+			Targets: []daprv1alpha1.DaprTrustBundleTarget{
+				{
+					Kind: "Secret",
+					Name: "dapr-trust-bundle",
+					KeyMappings: map[string]string{
+						"tls.crt": "issuer.crt",
+						"tls.key": "issuer.key",
+					},
+				},
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(sourceSecret, bundle).
+		WithStatusSubresource(bundle).
+		Build()
+
+	r := &DaprTrustBundleReconciler{Client: c}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "dapr-trust-bundle"}}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	target := &corev1.Secret{}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "dapr-trust-bundle"}, target); err != nil {
+		t.Fatalf("could not get target Secret: %v", err)
+	}
+	if string(target.Data["issuer.crt"]) != "cert-bytes" {
+		t.Errorf("target issuer.crt = %q, want %q", target.Data["issuer.crt"], "cert-bytes")
+	}
+	if string(target.Data["issuer.key"]) != "key-bytes" {
+		t.Errorf("target issuer.key = %q, want %q", target.Data["issuer.key"], "key-bytes")
+	}
+
+	updated := &daprv1alpha1.DaprTrustBundle{}
+	if err := c.Get(context.Background(), req.NamespacedName, updated); err != nil {
+		t.Fatalf("could not get DaprTrustBundle: %v", err)
+	}
+	synced := false
+	for _, cond := range updated.Status.Conditions {
+		if cond.Type == daprv1alpha1.ConditionSynced && cond.Status == metav1.ConditionTrue {
+			synced = true
+		}
+	}
+	if !synced {
+		t.Errorf("expected %s=True, got conditions %+v", daprv1alpha1.ConditionSynced, updated.Status.Conditions)
+	}
+}
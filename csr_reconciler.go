@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	certsv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// csrOwnerLabelKey and csrOwnerNamespaceLabelKey mark a
+	// CertificateSigningRequest as created for a given trust-bundle Secret,
+	// so the reconciler can find it again and mapCSRToSecret can route its
+	// events back to that Secret's reconcile request. A CertificateSigningRequest
+	// is cluster-scoped and can't carry an ownerReference to a namespaced
+	// Secret (cross-scope owner references are rejected by the API), so
+	// these labels are the correlation mechanism instead of an owner ref.
+	csrOwnerLabelKey          = "dapr-cert-transformer.safanaj.github.io/owner"
+	csrOwnerNamespaceLabelKey = "dapr-cert-transformer.safanaj.github.io/owner-namespace"
+	// csrPrivateKeySecretKey is the Data key under which the PEM private key
+	// generated alongside a CSR is stored, in a Secret named identically to
+	// the CSR. A CertificateSigningRequest is commonly readable by broader
+	// RBAC (approvers, automation, CSR viewers) than the trust-bundle Secret
+	// it's destined for, so the key is kept in a namespaced Secret rather
+	// than on the CSR itself. That Secret is deleted alongside the CSR as
+	// soon as it's consumed, which bounds how long the key lives outside
+	// the trust-bundle Secret.
+	csrPrivateKeySecretKey = "tls.key"
+)
+
+var (
+	enableCSRIssuer bool          = false
+	csrSignerName   string        = ""
+	csrRenewBefore  time.Duration = 24 * time.Hour
+)
+
+func parseCSRFlags() {
+	flag.BoolVar(&enableCSRIssuer, "enable-csr-issuer", enableCSRIssuer, "Mint issuer.crt/issuer.key via a CertificateSigningRequest instead of copying tls.crt/tls.key")
+	flag.StringVar(&csrSignerName, "csr-signer-name", csrSignerName, "signerName to request on the CertificateSigningRequest (required when --enable-csr-issuer is set)")
+	flag.DurationVar(&csrRenewBefore, "csr-renew-before", csrRenewBefore, "mint a new issuer certificate once the current one is within this window of expiring")
+}
+
+// CSRIssuerReconciler mints issuer.crt/issuer.key for the trust-bundle
+// Secret by driving a certificates.k8s.io/v1 CertificateSigningRequest,
+// instead of copying tls.crt/tls.key verbatim like DaprSecretReconciler
+// does. It is registered alongside DaprSecretReconciler and only active
+// when --enable-csr-issuer is set.
+type CSRIssuerReconciler struct {
+	client.Client
+}
+
+// csrPredicate is the event filter for the CertificateSigningRequest watch
+// feeding CSRIssuerReconciler. It cannot reuse daprSecretPredicate, which
+// matches trust-bundle Secrets by namespace/name or label: a CSR has
+// neither, so that predicate would discard every CSR event and the
+// reconciler would never see issued certificates. Instead it matches any
+// CertificateSigningRequest this process created, identified by
+// csrOwnerLabelKey.
+func csrPredicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(o client.Object) bool {
+		_, ok := o.GetLabels()[csrOwnerLabelKey]
+		return ok
+	})
+}
+
+// mapCSRToSecret maps a CertificateSigningRequest event to the reconcile
+// request for the trust-bundle Secret it was minted for. Owns() can't be
+// used here since ownerReferences cannot span from a cluster-scoped
+// CertificateSigningRequest to a namespaced Secret; csrOwnerLabelKey and
+// csrOwnerNamespaceLabelKey carry that correlation instead.
+func mapCSRToSecret(_ context.Context, o client.Object) []reconcile.Request {
+	name, ok := o.GetLabels()[csrOwnerLabelKey]
+	if !ok {
+		return nil
+	}
+	namespace, ok := o.GetLabels()[csrOwnerNamespaceLabelKey]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}}
+}
+
+func (r *CSRIssuerReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	l := logf.FromContext(ctx)
+
+	s := &corev1.Secret{}
+	if err := r.Get(ctx, req.NamespacedName, s); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		l.Error(err, "Failed to get secret")
+		return reconcile.Result{}, err
+	}
+
+	csrList := &certsv1.CertificateSigningRequestList{}
+	if err := r.List(ctx, csrList, client.MatchingLabels{csrOwnerLabelKey: s.Name, csrOwnerNamespaceLabelKey: s.Namespace}); err != nil {
+		l.Error(err, "Failed to list owned CertificateSigningRequests")
+		return reconcile.Result{}, err
+	}
+
+	for i := range csrList.Items {
+		csr := &csrList.Items[i]
+		if reason, ok := csrDeniedOrFailed(csr); ok {
+			l.Info("CertificateSigningRequest was denied or failed, deleting so a new one can be requested", "csr", csr.Name, "reason", reason)
+			return reconcile.Result{}, r.deleteCSRAndKey(ctx, csr, s.Namespace)
+		}
+		if len(csr.Status.Certificate) == 0 {
+			l.V(4).Info("CertificateSigningRequest not yet issued", "csr", csr.Name)
+			return reconcile.Result{}, nil
+		}
+
+		keySecret := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: s.Namespace, Name: csr.Name}, keySecret); err != nil {
+			if !apierrors.IsNotFound(err) {
+				l.Error(err, "Failed to get private key Secret for issued CertificateSigningRequest")
+				return reconcile.Result{}, err
+			}
+			l.Error(err, "Private key Secret for issued CertificateSigningRequest is gone, deleting unusable CertificateSigningRequest", "csr", csr.Name)
+			return reconcile.Result{}, r.deleteCSRAndKey(ctx, csr, s.Namespace)
+		}
+		keyPEM, ok := keySecret.Data[csrPrivateKeySecretKey]
+		if !ok {
+			l.Error(fmt.Errorf("missing %s key", csrPrivateKeySecretKey), "Deleting unusable CertificateSigningRequest", "csr", csr.Name)
+			return reconcile.Result{}, r.deleteCSRAndKey(ctx, csr, s.Namespace)
+		}
+
+		s.Data[certDestKey] = csr.Status.Certificate
+		s.Data[keyDestKey] = keyPEM
+		if err := r.Update(ctx, s); err != nil {
+			l.Error(err, "Failed to update secret with issued certificate")
+			return reconcile.Result{}, err
+		}
+
+		if err := r.deleteCSRAndKey(ctx, csr, s.Namespace); err != nil {
+			return reconcile.Result{}, err
+		}
+
+		l.Info("Issued new issuer certificate via CertificateSigningRequest", "csr", csr.Name)
+		return reconcile.Result{}, nil
+	}
+
+	if !needsIssuerRenewal(s, csrRenewBefore) {
+		l.V(4).Info("issuer certificate not due for renewal")
+		return reconcile.Result{}, nil
+	}
+
+	keyPEM, csrPEM, err := generateKeyAndCSR(s.Namespace + "." + s.Name)
+	if err != nil {
+		l.Error(err, "Failed to generate private key and CSR")
+		return reconcile.Result{}, err
+	}
+
+	name, err := generateCSRName(s.Name)
+	if err != nil {
+		l.Error(err, "Failed to generate CertificateSigningRequest name")
+		return reconcile.Result{}, err
+	}
+
+	labels := map[string]string{
+		csrOwnerLabelKey:          s.Name,
+		csrOwnerNamespaceLabelKey: s.Namespace,
+	}
+
+	keySecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: s.Namespace,
+			Labels:    labels,
+		},
+		Data: map[string][]byte{csrPrivateKeySecretKey: keyPEM},
+	}
+	if err := controllerutil.SetOwnerReference(s, keySecret, r.Scheme()); err != nil {
+		l.Error(err, "Failed to set owner reference on private key Secret")
+		return reconcile.Result{}, err
+	}
+	if err := r.Create(ctx, keySecret); err != nil {
+		l.Error(err, "Failed to create private key Secret")
+		return reconcile.Result{}, err
+	}
+
+	csr := &certsv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: certsv1.CertificateSigningRequestSpec{
+			Request:    csrPEM,
+			SignerName: csrSignerName,
+			Usages: []certsv1.KeyUsage{
+				certsv1.UsageDigitalSignature,
+				certsv1.UsageKeyEncipherment,
+				certsv1.UsageCertSign,
+			},
+		},
+	}
+
+	if err := r.Create(ctx, csr); err != nil {
+		l.Error(err, "Failed to create CertificateSigningRequest, deleting orphaned private key Secret")
+		if delErr := r.Delete(ctx, keySecret); delErr != nil && !apierrors.IsNotFound(delErr) {
+			l.Error(delErr, "Failed to delete orphaned private key Secret", "secret", keySecret.Name)
+		}
+		return reconcile.Result{}, err
+	}
+
+	l.Info("Requested new issuer certificate", "csr", csr.Name, "signerName", csrSignerName)
+	return reconcile.Result{}, nil
+}
+
+// deleteCSRAndKey deletes csr and its paired private key Secret (named
+// identically, in keyNamespace). Both are best-effort deletes: a missing
+// object is not an error, since either may already be gone.
+func (r *CSRIssuerReconciler) deleteCSRAndKey(ctx context.Context, csr *certsv1.CertificateSigningRequest, keyNamespace string) error {
+	if err := client.IgnoreNotFound(r.Delete(ctx, csr)); err != nil {
+		return fmt.Errorf("could not delete CertificateSigningRequest %s: %w", csr.Name, err)
+	}
+	keySecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: csr.Name, Namespace: keyNamespace}}
+	if err := client.IgnoreNotFound(r.Delete(ctx, keySecret)); err != nil {
+		return fmt.Errorf("could not delete private key Secret %s/%s: %w", keyNamespace, csr.Name, err)
+	}
+	return nil
+}
+
+// generateCSRName returns a random name for a CSR/private-key-Secret pair,
+// prefixed with the trust-bundle Secret's name. A name is generated up
+// front, instead of relying on GenerateName, so the same name can be used
+// for both the CSR and its paired Secret.
+func generateCSRName(prefix string) (string, error) {
+	suffix := make([]byte, 5)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("could not generate random suffix: %w", err)
+	}
+	return fmt.Sprintf("%s-issuer-%s", prefix, hex.EncodeToString(suffix)), nil
+}
+
+// csrDeniedOrFailed reports whether csr has a Denied or Failed condition set
+// to True, returning the condition type as reason. Without this check a
+// rejected CSR is indistinguishable from one still pending approval, since
+// both have an empty Status.Certificate, and issuance would stall forever
+// with no way to recover.
+func csrDeniedOrFailed(csr *certsv1.CertificateSigningRequest) (string, bool) {
+	for _, cond := range csr.Status.Conditions {
+		if (cond.Type == certsv1.CertificateDenied || cond.Type == certsv1.CertificateFailed) && cond.Status == corev1.ConditionTrue {
+			return string(cond.Type), true
+		}
+	}
+	return "", false
+}
+
+// needsIssuerRenewal reports whether certDestKey is missing, unparsable, or
+// within renewBefore of expiring.
+func needsIssuerRenewal(s *corev1.Secret, renewBefore time.Duration) bool {
+	crtPEM, ok := s.Data[certDestKey]
+	if !ok {
+		return true
+	}
+	block, _ := pem.Decode(crtPEM)
+	if block == nil {
+		return true
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return true
+	}
+	return time.Now().Add(renewBefore).After(cert.NotAfter)
+}
+
+// generateKeyAndCSR creates a fresh ECDSA P-256 private key and a PEM-encoded
+// PKCS#10 CertificateRequest for it, returning the PEM private key and the
+// PEM CSR respectively.
+func generateKeyAndCSR(commonName string) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not generate private key: %w", err)
+	}
+
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not marshal private key: %w", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+
+	template := &x509.CertificateRequest{
+		Subject:            pkix.Name{CommonName: commonName},
+		SignatureAlgorithm: x509.ECDSAWithSHA256,
+	}
+	csrBytes, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create certificate signing request: %w", err)
+	}
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrBytes})
+
+	return keyPEM, csrPEM, nil
+}
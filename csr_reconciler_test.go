@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNeedsIssuerRenewal(t *testing.T) {
+	now := time.Now()
+	renewBefore := time.Hour
+
+	validCertPEM, _ := generateTestCert(t, now.Add(-time.Hour), now.Add(2*time.Hour), false)
+	soonToExpireCertPEM, _ := generateTestCert(t, now.Add(-time.Hour), now.Add(30*time.Minute), false)
+
+	tests := []struct {
+		name string
+		data map[string][]byte
+		want bool
+	}{
+		{name: "missing certDestKey", data: map[string][]byte{}, want: true},
+		{name: "unparsable PEM", data: map[string][]byte{certDestKey: []byte("not a pem")}, want: true},
+		{name: "far from expiry", data: map[string][]byte{certDestKey: validCertPEM}, want: false},
+		{name: "within renewal window", data: map[string][]byte{certDestKey: soonToExpireCertPEM}, want: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &corev1.Secret{Data: tc.data}
+			if got := needsIssuerRenewal(s, renewBefore); got != tc.want {
+				t.Fatalf("needsIssuerRenewal() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
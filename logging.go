@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/zapr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var logInfoStdout bool = false
+
+func parseLoggingFlags() {
+	flag.BoolVar(&logInfoStdout, "log-info-stdout", logInfoStdout, "write logs below error level to stdout and error-level logs to stderr, instead of everything to stderr")
+}
+
+// buildLogger returns the root logr.Logger for the process. With
+// --log-info-stdout it splits the stream in two zapcore.Cores so info logs
+// go to stdout and errors go to stderr, which plays nicer with log
+// collectors that treat stderr as an error signal. Without the flag it
+// behaves like the previous zap.New(), everything on stderr.
+func buildLogger(infoStdout bool) logr.Logger {
+	encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+
+	if !infoStdout {
+		core := zapcore.NewCore(encoder, zapcore.Lock(os.Stderr), zapcore.DebugLevel)
+		return zapr.NewLogger(zap.New(core))
+	}
+
+	infoCore := zapcore.NewCore(encoder, zapcore.Lock(os.Stdout), zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return lvl < zapcore.ErrorLevel
+	}))
+	errorCore := zapcore.NewCore(encoder, zapcore.Lock(os.Stderr), zapcore.ErrorLevel)
+
+	return zapr.NewLogger(zap.New(zapcore.NewTee(infoCore, errorCore)))
+}
@@ -5,22 +5,35 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	goflag "flag"
 	flag "github.com/spf13/pflag"
 
+	"github.com/go-logr/logr"
+	certsv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
-	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	daprv1alpha1 "github.com/safanaj/dapr-cert-transformer/api/v1alpha1"
+	"github.com/safanaj/dapr-cert-transformer/controllers"
 )
 
 var (
@@ -34,6 +47,11 @@ var (
 	certDestKey              string = "issuer.crt"
 	keySourceKey             string = "tls.key"
 	keyDestKey               string = "issuer.key"
+
+	allNamespaces       bool   = false
+	watchNamespacesFlag string = ""
+	secretSelectorFlag  string = ""
+	secretSelector      labels.Selector
 )
 
 func parseFlags() {
@@ -44,20 +62,39 @@ func parseFlags() {
 	flag.StringVar(&certDestKey, "dest-cert-secret-key", certDestKey, "")
 	flag.StringVar(&keySourceKey, "source-key-secret-key", keySourceKey, "")
 	flag.StringVar(&keyDestKey, "dest-key-secret-key", keyDestKey, "")
+	flag.BoolVar(&allNamespaces, "all-namespaces", allNamespaces, "watch Secrets cluster-wide instead of only in watch-secret-namespace")
+	flag.StringVar(&watchNamespacesFlag, "watch-namespaces", watchNamespacesFlag, "comma-separated list of additional namespaces to watch alongside watch-secret-namespace; lets one process reconcile trust bundles across several namespaces. Ignored when --all-namespaces is set")
+	flag.StringVar(&secretSelectorFlag, "secret-selector", secretSelectorFlag, "label selector matching trust-bundle Secrets, in place of the watch-secret-namespace/watch-secret-name pair; lets one process reconcile several trust bundles")
 	flag.CommandLine.AddGoFlag(goflag.Lookup("kubeconfig"))
+	parseCSRFlags()
+	parseValidationFlags()
+	parseMultiClusterFlags()
+	parseLoggingFlags()
 	flag.Parse()
 }
 
-func isDaprTrustBundleSecret(o client.ObjectKey) bool {
-	return o.Namespace == daprTrustBundleNamespace && o.Name == daprTrustBundleName
+// isDaprTrustBundleSecret reports whether o is a trust-bundle Secret this
+// process should reconcile. With --secret-selector set, o is matched by
+// label across every watched namespace; otherwise it falls back to the
+// original watch-secret-namespace/watch-secret-name equality check.
+func isDaprTrustBundleSecret(o client.Object) bool {
+	if secretSelector != nil {
+		return secretSelector.Matches(labels.Set(o.GetLabels()))
+	}
+	return o.GetNamespace() == daprTrustBundleNamespace && o.GetName() == daprTrustBundleName
 }
 
-func needsDaprTrustBundleSecretUpdate(s *corev1.Secret) bool {
-	tlsCrt, tlsCrtOk := s.Data[certSourceKey]
-	tlsKey, tlsKeyOk := s.Data[keySourceKey]
-	issuerCrt, _ := s.Data[certDestKey]
-	issuerKey, _ := s.Data[keyDestKey]
-	return (tlsCrtOk && tlsKeyOk && (string(tlsCrt) != string(issuerCrt) || string(tlsKey) != string(issuerKey)))
+// daprSecretPredicate is the event filter shared by every controller that
+// watches trust-bundle Secrets.
+func daprSecretPredicate() predicate.Predicate {
+	return predicate.And(predicate.ResourceVersionChangedPredicate{}, predicate.Funcs{
+		CreateFunc: func(evt event.CreateEvent) bool {
+			return isDaprTrustBundleSecret(evt.Object)
+		},
+		UpdateFunc: func(evt event.UpdateEvent) bool {
+			return isDaprTrustBundleSecret(evt.ObjectNew)
+		},
+	})
 }
 
 func main() {
@@ -68,9 +105,10 @@ func main() {
 		os.Exit(0)
 	}
 
-	logf.SetLogger(zap.New())
+	logf.SetLogger(buildLogger(logInfoStdout))
 
 	log := logf.Log.WithName(progname)
+	ctx := logr.NewContext(context.Background(), log)
 
 	if daprTrustBundleNamespace == "" {
 		podNs := os.Getenv("POD_NAMESPACE")
@@ -81,7 +119,37 @@ func main() {
 		daprTrustBundleNamespace = podNs
 	}
 
-	mgr, err := manager.New(config.GetConfigOrDie(), manager.Options{Namespace: daprTrustBundleNamespace, Logger: log.WithName("mgr")})
+	if secretSelectorFlag != "" {
+		sel, err := labels.Parse(secretSelectorFlag)
+		if err != nil {
+			log.Error(err, "invalid --secret-selector")
+			os.Exit(1)
+		}
+		secretSelector = sel
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		log.Error(err, "could not register client-go scheme")
+		os.Exit(1)
+	}
+	if err := daprv1alpha1.AddToScheme(scheme); err != nil {
+		log.Error(err, "could not register dapr.io/v1alpha1 scheme")
+		os.Exit(1)
+	}
+
+	mgrOptions := manager.Options{Scheme: scheme, Logger: log.WithName("mgr")}
+	if !allNamespaces {
+		watchNamespaces := map[string]cache.Config{daprTrustBundleNamespace: {}}
+		for _, ns := range strings.Split(watchNamespacesFlag, ",") {
+			if ns = strings.TrimSpace(ns); ns != "" {
+				watchNamespaces[ns] = cache.Config{}
+			}
+		}
+		mgrOptions.Cache = cache.Options{DefaultNamespaces: watchNamespaces}
+	}
+
+	mgr, err := manager.New(config.GetConfigOrDie(), mgrOptions)
 	if err != nil {
 		log.Error(err, "could not create manager")
 		os.Exit(1)
@@ -99,19 +167,68 @@ func main() {
 		return nil
 	})
 
-	// cl := mgr.GetClient()
+	if err := NewDaprSecretReconciler(mgr.GetClient(), mgr.GetEventRecorderFor(progname)).SetupWithManager(mgr); err != nil {
+		log.Error(err, "could not set up DaprSecretReconciler")
+		os.Exit(1)
+	}
+
+	trustBundleReconciler := &controllers.DaprTrustBundleReconciler{Client: mgr.GetClient()}
 	err = builder.
 		ControllerManagedBy(mgr).
-		For(&corev1.Secret{}).
-		WithEventFilter(predicate.And(predicate.ResourceVersionChangedPredicate{}, predicate.Funcs{
-			CreateFunc: func(evt event.CreateEvent) bool {
-				return isDaprTrustBundleSecret(client.ObjectKeyFromObject(evt.Object))
-			},
-			UpdateFunc: func(evt event.UpdateEvent) bool {
-				return isDaprTrustBundleSecret(client.ObjectKeyFromObject(evt.ObjectNew))
-			},
-		})).
-		Complete(&DaprSecretReconciler{})
+		For(&daprv1alpha1.DaprTrustBundle{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(controllers.MapTargetToBundle)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(controllers.MapTargetToBundle)).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(trustBundleReconciler.MapNamespaceToBundles)).
+		Complete(trustBundleReconciler)
+	if err != nil {
+		log.Error(err, "could not set up DaprTrustBundleReconciler")
+		os.Exit(1)
+	}
+
+	if enableCSRIssuer {
+		if csrSignerName == "" {
+			log.Error(fmt.Errorf("missing --csr-signer-name"), "--csr-signer-name is required when --enable-csr-issuer is set")
+			os.Exit(1)
+		}
+
+		mgr.AddReadyzCheck("csr-ready", func(_ *http.Request) error {
+			i, err := mgr.GetCache().GetInformer(context.TODO(), &certsv1.CertificateSigningRequest{})
+			if err != nil {
+				return err
+			}
+			if !i.HasSynced() {
+				return fmt.Errorf("CertificateSigningRequest informer not in sync")
+			}
+			return nil
+		})
+
+		err = builder.
+			ControllerManagedBy(mgr).
+			For(&corev1.Secret{}, builder.WithPredicates(daprSecretPredicate())).
+			Watches(&certsv1.CertificateSigningRequest{}, handler.EnqueueRequestsFromMapFunc(mapCSRToSecret), builder.WithPredicates(csrPredicate())).
+			Complete(&CSRIssuerReconciler{Client: mgr.GetClient()})
+		if err != nil {
+			log.Error(err, "could not set up CSRIssuerReconciler")
+			os.Exit(1)
+		}
+	}
+
+	remotes, err := discoverRemoteClusters(ctx, mgr, daprTrustBundleNamespace)
+	if err != nil {
+		log.Error(err, "could not discover remote clusters")
+		os.Exit(1)
+	}
+	if len(remotes) > 0 {
+		err = builder.
+			ControllerManagedBy(mgr).
+			For(&corev1.Secret{}).
+			WithEventFilter(daprSecretPredicate()).
+			Complete(&RemoteSyncReconciler{Client: mgr.GetClient(), Remotes: remotes})
+		if err != nil {
+			log.Error(err, "could not set up RemoteSyncReconciler")
+			os.Exit(1)
+		}
+	}
 
 	if err := mgr.Start(signals.SetupSignalHandler()); err != nil {
 		log.Error(err, "could not start manager")
@@ -119,28 +236,67 @@ func main() {
 	}
 }
 
-// ReplicaSetReconciler is a simple ControllerManagedBy example implementation.
+// DaprSecretReconciler copies the source certificate/key pair of the
+// trust-bundle Secret into its issuer.crt/issuer.key keys.
 type DaprSecretReconciler struct {
 	client.Client
+	Recorder record.EventRecorder
 }
 
-func (a *DaprSecretReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+// NewDaprSecretReconciler builds a DaprSecretReconciler with its
+// dependencies injected through the constructor, in place of the
+// deprecated InjectClient(client.Client) pattern.
+func NewDaprSecretReconciler(c client.Client, recorder record.EventRecorder) *DaprSecretReconciler {
+	return &DaprSecretReconciler{Client: c, Recorder: recorder}
+}
+
+// SetupWithManager registers the reconciler with mgr using the typed
+// builder/reconciler APIs.
+func (a *DaprSecretReconciler) SetupWithManager(mgr manager.Manager) error {
+	return builder.
+		TypedControllerManagedBy[reconcile.Request](mgr).
+		For(&corev1.Secret{}).
+		WithEventFilter(daprSecretPredicate()).
+		Complete(a)
+}
+
+var _ reconcile.TypedReconciler[reconcile.Request] = &DaprSecretReconciler{}
+
+func (a *DaprSecretReconciler) Reconcile(ctx context.Context, req reconcile.Request) (result reconcile.Result, retErr error) {
 	l := logf.FromContext(ctx)
 
+	start := time.Now()
+	reconcileResult := "skipped"
+	defer func() {
+		if retErr != nil {
+			reconcileResult = "error"
+		}
+		reconcileTotal.WithLabelValues(reconcileResult).Inc()
+		reconcileDuration.WithLabelValues(reconcileResult).Observe(time.Since(start).Seconds())
+	}()
+
 	l.V(4).Info("DaprSecretReconciler.Reconcile ...")
-	if !isDaprTrustBundleSecret(req.NamespacedName) {
-		l.Info("Avoid reconciling other secret")
-		return reconcile.Result{}, nil
-	}
 
 	s := &corev1.Secret{}
-	err := a.Get(ctx, req.NamespacedName, s)
-	if err != nil {
+	if err := a.Get(ctx, req.NamespacedName, s); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
 		l.Error(err, "Failed to get secret")
 		return reconcile.Result{}, err
 	}
 
-	if !needsDaprTrustBundleSecretUpdate(s) {
+	if !isDaprTrustBundleSecret(s) {
+		l.Info("Avoid reconciling other secret")
+		return reconcile.Result{}, nil
+	}
+
+	if cert, err := parseCertificatePEM(s.Data[certDestKey]); err == nil {
+		certExpirySeconds.WithLabelValues(req.String()).Set(float64(cert.NotAfter.Unix()))
+	}
+
+	if !needsDaprTrustBundleSecretUpdate(l, a.Recorder, s) {
+		reconcileResult = "unchanged"
 		l.Info("Already up-to-date")
 		return reconcile.Result{}, nil
 	}
@@ -148,17 +304,18 @@ func (a *DaprSecretReconciler) Reconcile(ctx context.Context, req reconcile.Requ
 	s.Data[certDestKey] = s.Data[certSourceKey]
 	s.Data[keyDestKey] = s.Data[keySourceKey]
 
-	err = a.Update(ctx, s)
-	if err != nil {
+	if err := a.Update(ctx, s); err != nil {
 		l.Error(err, "Failed to update secret")
 		return reconcile.Result{}, fmt.Errorf("could not update Secret: %+v", err)
 	}
 
+	reconcileResult = "updated"
+	secretUpdateTotal.Inc()
+	if cert, err := parseCertificatePEM(s.Data[certDestKey]); err == nil {
+		certExpirySeconds.WithLabelValues(req.String()).Set(float64(cert.NotAfter.Unix()))
+	}
+	a.Recorder.Event(s, corev1.EventTypeNormal, "Updated", "Updated issuer certificate from source certificate")
+
 	l.Info("Successful reconciliation")
 	return reconcile.Result{}, nil
 }
-
-func (a *DaprSecretReconciler) InjectClient(c client.Client) error {
-	a.Client = c
-	return nil
-}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	reconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dapr_cert_transformer_reconcile_total",
+		Help: "Total number of DaprSecretReconciler reconciles, by result.",
+	}, []string{"result"})
+
+	secretUpdateTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "dapr_cert_transformer_secret_update_total",
+		Help: "Total number of times the trust-bundle Secret was updated with a new issuer certificate.",
+	})
+
+	certExpirySeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "dapr_cert_transformer_cert_expiry_seconds",
+		Help: "Unix time at which the current destination certificate in a Secret expires.",
+	}, []string{"secret"})
+
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "dapr_cert_transformer_reconcile_duration_seconds",
+		Help: "Time taken by each DaprSecretReconciler reconcile.",
+	}, []string{"result"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(reconcileTotal, secretUpdateTotal, certExpirySeconds, reconcileDuration)
+}
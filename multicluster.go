@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// kubeconfigSecretKey is the Secret data key holding the remote
+	// cluster's kubeconfig.
+	kubeconfigSecretKey = "kubeconfig"
+	// remoteTargetNamespaceAnnotation/remoteTargetNameAnnotation override
+	// where the trust-bundle Data is written on the remote cluster. They
+	// default to the local watch-secret-namespace/watch-secret-name.
+	remoteTargetNamespaceAnnotation = "dapr-cert-transformer.safanaj.github.io/target-namespace"
+	remoteTargetNameAnnotation      = "dapr-cert-transformer.safanaj.github.io/target-name"
+
+	remoteConditionHealthy = "Healthy"
+)
+
+var (
+	remoteClusterSelector string        = ""
+	remoteStatusConfigMap string        = "dapr-cert-transformer-remotes"
+	remoteBackoffBase     time.Duration = 5 * time.Second
+	remoteBackoffMax      time.Duration = 5 * time.Minute
+)
+
+func parseMultiClusterFlags() {
+	flag.StringVar(&remoteClusterSelector, "remote-cluster-selector", remoteClusterSelector, "label selector, in the operator namespace, matching kubeconfig Secrets for remote clusters to propagate the trust bundle to")
+	flag.StringVar(&remoteStatusConfigMap, "remote-status-configmap", remoteStatusConfigMap, "name of the ConfigMap, in the operator namespace, used to report per-remote-cluster sync health")
+}
+
+// remoteTarget is one remote cluster the trust bundle is propagated to.
+type remoteTarget struct {
+	name      string
+	cluster   cluster.Cluster
+	namespace string
+	secret    string
+}
+
+// discoverRemoteClusters lists the kubeconfig Secrets matching
+// --remote-cluster-selector in namespace, builds a cluster.Cluster for each
+// and registers it with mgr so its cache/client are started and stopped
+// alongside the manager, mirroring controller-runtime's multiclustersync
+// example.
+func discoverRemoteClusters(ctx context.Context, mgr manager.Manager, namespace string) ([]remoteTarget, error) {
+	l := logf.FromContext(ctx)
+
+	if remoteClusterSelector == "" {
+		return nil, nil
+	}
+
+	sel, err := labels.Parse(remoteClusterSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --remote-cluster-selector: %w", err)
+	}
+
+	// A direct, uncached client is used here because the manager's cache
+	// has not started yet at this point in setup.
+	c, err := client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme()})
+	if err != nil {
+		return nil, fmt.Errorf("could not create client to discover remote clusters: %w", err)
+	}
+
+	secretList := &corev1.SecretList{}
+	if err := c.List(ctx, secretList, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: sel}); err != nil {
+		return nil, fmt.Errorf("could not list kubeconfig Secrets: %w", err)
+	}
+
+	targets := make([]remoteTarget, 0, len(secretList.Items))
+	for _, s := range secretList.Items {
+		kubeconfig, ok := s.Data[kubeconfigSecretKey]
+		if !ok {
+			return nil, fmt.Errorf("Secret %s/%s has no %q key", s.Namespace, s.Name, kubeconfigSecretKey)
+		}
+
+		restCfg, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kubeconfig in Secret %s/%s: %w", s.Namespace, s.Name, err)
+		}
+
+		remoteCluster, err := cluster.New(restCfg, func(o *cluster.Options) {
+			o.Scheme = mgr.GetScheme()
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not build cluster for Secret %s/%s: %w", s.Namespace, s.Name, err)
+		}
+		if err := mgr.Add(remoteCluster); err != nil {
+			return nil, fmt.Errorf("could not register remote cluster %s/%s with manager: %w", s.Namespace, s.Name, err)
+		}
+
+		target := remoteTarget{name: s.Name, cluster: remoteCluster, namespace: s.Annotations[remoteTargetNamespaceAnnotation], secret: s.Annotations[remoteTargetNameAnnotation]}
+		if target.namespace == "" {
+			target.namespace = daprTrustBundleNamespace
+		}
+		if target.secret == "" {
+			target.secret = daprTrustBundleName
+		}
+		l.Info("Registered remote cluster", "remote", target.name, "targetNamespace", target.namespace, "targetSecret", target.secret)
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+// RemoteSyncReconciler watches the local trust-bundle Secret and, on every
+// reconcile, pushes its issuer.crt/issuer.key into the equivalent Secret on
+// every remote cluster discovered by discoverRemoteClusters. Connectivity
+// failures to a remote are requeued with exponential backoff and recorded
+// on remoteStatusConfigMap so a federated Dapr control plane can be
+// monitored from a single place.
+type RemoteSyncReconciler struct {
+	client.Client
+	Remotes []remoteTarget
+
+	backoff map[string]int
+}
+
+func (r *RemoteSyncReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	l := logf.FromContext(ctx)
+
+	if len(r.Remotes) == 0 {
+		return reconcile.Result{}, nil
+	}
+
+	s := &corev1.Secret{}
+	if err := r.Get(ctx, req.NamespacedName, s); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if !isDaprTrustBundleSecret(s) {
+		return reconcile.Result{}, nil
+	}
+
+	issuerCrt, crtOk := s.Data[certDestKey]
+	issuerKey, keyOk := s.Data[keyDestKey]
+	if !crtOk || !keyOk {
+		l.V(4).Info("issuer certificate not yet populated locally, nothing to propagate")
+		return reconcile.Result{}, nil
+	}
+
+	if r.backoff == nil {
+		r.backoff = map[string]int{}
+	}
+
+	health := map[string]string{}
+	var requeueAfter time.Duration
+	for _, remote := range r.Remotes {
+		err := r.syncRemote(ctx, remote, issuerCrt, issuerKey)
+		if err != nil {
+			l.Error(err, "Failed to sync trust bundle to remote cluster", "remote", remote.name)
+			health[remote.name] = fmt.Sprintf("%s=False: %s", remoteConditionHealthy, err.Error())
+
+			r.backoff[remote.name]++
+			wait := backoffFor(r.backoff[remote.name])
+			if requeueAfter == 0 || wait < requeueAfter {
+				requeueAfter = wait
+			}
+			continue
+		}
+
+		health[remote.name] = remoteConditionHealthy + "=True"
+		delete(r.backoff, remote.name)
+	}
+
+	if err := r.updateStatusConfigMap(ctx, health); err != nil {
+		l.Error(err, "Failed to update remote status ConfigMap")
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
+
+func (r *RemoteSyncReconciler) syncRemote(ctx context.Context, remote remoteTarget, issuerCrt, issuerKey []byte) error {
+	remoteClient := remote.cluster.GetClient()
+
+	obj := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: remote.secret, Namespace: remote.namespace}}
+	_, err := controllerutil.CreateOrUpdate(ctx, remoteClient, obj, func() error {
+		if obj.Data == nil {
+			obj.Data = map[string][]byte{}
+		}
+		obj.Data[certDestKey] = issuerCrt
+		obj.Data[keyDestKey] = issuerKey
+		return nil
+	})
+	return err
+}
+
+// updateStatusConfigMap records, for each remote, a "Healthy" condition as a
+// single Data entry so `kubectl get configmap -o yaml` gives an at-a-glance
+// view of federation health.
+func (r *RemoteSyncReconciler) updateStatusConfigMap(ctx context.Context, health map[string]string) error {
+	cm := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: daprTrustBundleNamespace, Name: remoteStatusConfigMap}, cm)
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: daprTrustBundleNamespace, Name: remoteStatusConfigMap}}
+		cm.Data = health
+		return r.Create(ctx, cm)
+	}
+	if err != nil {
+		return err
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	for k, v := range health {
+		cm.Data[k] = v
+	}
+	return r.Update(ctx, cm)
+}
+
+// backoffFor returns an exponential backoff duration for the given
+// (1-indexed) consecutive failure count, capped at remoteBackoffMax.
+func backoffFor(failures int) time.Duration {
+	d := remoteBackoffBase
+	for i := 1; i < failures; i++ {
+		d *= 2
+		if d >= remoteBackoffMax {
+			return remoteBackoffMax
+		}
+	}
+	return d
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+var (
+	rotateBefore time.Duration = 30 * 24 * time.Hour
+	requireCA    bool          = false
+)
+
+func parseValidationFlags() {
+	flag.DurationVar(&rotateBefore, "rotate-before", rotateBefore, "only rotate the issuer certificate ahead of its expiry once it is within this window")
+	flag.BoolVar(&requireCA, "require-ca", requireCA, "reject source certificates that are not CA certificates (BasicConstraints.IsCA)")
+}
+
+// needsDaprTrustBundleSecretUpdate reports whether certDestKey/keyDestKey
+// should be overwritten from certSourceKey/keySourceKey. Beyond the raw byte
+// comparison, it parses the source as a well-formed, non-expired X.509
+// certificate whose public key matches the source private key, rejects it
+// when --require-ca is set and it is not a CA certificate, and otherwise
+// only allows the overwrite when the source is newer than the current
+// destination certificate or the destination is within --rotate-before of
+// expiring. Rejections are logged at Info level and surfaced as a Warning
+// Event on s, so a bad rollout of the source data doesn't silently stamp
+// garbage into the issuer certificate.
+func needsDaprTrustBundleSecretUpdate(l logr.Logger, recorder record.EventRecorder, s *corev1.Secret) bool {
+	tlsCrt, tlsCrtOk := s.Data[certSourceKey]
+	tlsKey, tlsKeyOk := s.Data[keySourceKey]
+	if !tlsCrtOk || !tlsKeyOk {
+		return false
+	}
+
+	issuerCrt := s.Data[certDestKey]
+	issuerKey := s.Data[keyDestKey]
+	if string(tlsCrt) == string(issuerCrt) && string(tlsKey) == string(issuerKey) {
+		return false
+	}
+
+	sourceCert, err := validateSourceCertificate(tlsCrt, tlsKey)
+	if err != nil {
+		l.Info("Rejecting source certificate", "reason", err.Error())
+		recorder.Event(s, corev1.EventTypeWarning, "ValidationFailed", err.Error())
+		return false
+	}
+
+	if len(issuerCrt) > 0 {
+		if destCert, err := parseCertificatePEM(issuerCrt); err == nil {
+			rotationDue := time.Until(destCert.NotAfter) <= rotateBefore
+			if !sourceCert.NotBefore.After(destCert.NotBefore) && !rotationDue {
+				l.Info("Rejecting source certificate: not newer than the current issuer certificate and not within the rotation window",
+					"sourceNotBefore", sourceCert.NotBefore, "destNotBefore", destCert.NotBefore, "destNotAfter", destCert.NotAfter, "rotateBefore", rotateBefore)
+				recorder.Event(s, corev1.EventTypeWarning, "ValidationFailed", "source certificate is not newer than the current issuer certificate and the current one is not within the rotation window")
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// validateSourceCertificate parses certPEM/keyPEM as a matching certificate
+// and private key pair and checks that the certificate is currently valid
+// (and, if --require-ca is set, that it is a CA certificate).
+func validateSourceCertificate(certPEM, keyPEM []byte) (*x509.Certificate, error) {
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("certificate and key do not form a valid pair: %w", err)
+	}
+
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("could not parse certificate: %w", err)
+	}
+
+	now := time.Now()
+	if now.Before(cert.NotBefore) {
+		return nil, fmt.Errorf("certificate is not valid until %s", cert.NotBefore)
+	}
+	if now.After(cert.NotAfter) {
+		return nil, fmt.Errorf("certificate expired at %s", cert.NotAfter)
+	}
+	if requireCA && !(cert.IsCA && cert.BasicConstraintsValid) {
+		return nil, fmt.Errorf("certificate is not a CA certificate")
+	}
+
+	return cert, nil
+}
+
+func parseCertificatePEM(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, fmt.Errorf("could not decode PEM block")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// generateTestCert returns a self-signed EC certificate/key PEM pair with the
+// given validity window and CA bit, for exercising validateSourceCertificate
+// and the rotation math in needsDaprTrustBundleSecretUpdate without a live
+// cluster.
+func generateTestCert(t *testing.T, notBefore, notAfter time.Time, isCA bool) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("could not create certificate: %v", err)
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("could not marshal key: %v", err)
+	}
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+
+	return certPEM, keyPEM
+}
+
+func TestValidateSourceCertificate(t *testing.T) {
+	now := time.Now()
+	validCertPEM, validKeyPEM := generateTestCert(t, now.Add(-time.Hour), now.Add(time.Hour), false)
+	caCertPEM, caKeyPEM := generateTestCert(t, now.Add(-time.Hour), now.Add(time.Hour), true)
+	notYetValidCertPEM, notYetValidKeyPEM := generateTestCert(t, now.Add(time.Hour), now.Add(2*time.Hour), false)
+	expiredCertPEM, expiredKeyPEM := generateTestCert(t, now.Add(-2*time.Hour), now.Add(-time.Hour), false)
+	_, otherKeyPEM := generateTestCert(t, now.Add(-time.Hour), now.Add(time.Hour), false)
+
+	tests := []struct {
+		name      string
+		certPEM   []byte
+		keyPEM    []byte
+		requireCA bool
+		wantErr   bool
+	}{
+		{name: "valid certificate", certPEM: validCertPEM, keyPEM: validKeyPEM},
+		{name: "not yet valid", certPEM: notYetValidCertPEM, keyPEM: notYetValidKeyPEM, wantErr: true},
+		{name: "expired", certPEM: expiredCertPEM, keyPEM: expiredKeyPEM, wantErr: true},
+		{name: "mismatched key", certPEM: validCertPEM, keyPEM: otherKeyPEM, wantErr: true},
+		{name: "require-ca rejects non-CA certificate", certPEM: validCertPEM, keyPEM: validKeyPEM, requireCA: true, wantErr: true},
+		{name: "require-ca accepts CA certificate", certPEM: caCertPEM, keyPEM: caKeyPEM, requireCA: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			origRequireCA := requireCA
+			requireCA = tc.requireCA
+			defer func() { requireCA = origRequireCA }()
+
+			_, err := validateSourceCertificate(tc.certPEM, tc.keyPEM)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("validateSourceCertificate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestNeedsDaprTrustBundleSecretUpdate(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name         string
+		sourceWindow [2]time.Duration // NotBefore, NotAfter offsets from now
+		destWindow   *[2]time.Duration
+		rotateBefore time.Duration
+		want         bool
+	}{
+		{
+			name:         "no destination yet: always update",
+			sourceWindow: [2]time.Duration{-time.Hour, time.Hour},
+			rotateBefore: time.Hour,
+			want:         true,
+		},
+		{
+			name:         "source newer than destination: update",
+			sourceWindow: [2]time.Duration{-time.Minute, time.Hour},
+			destWindow:   &[2]time.Duration{-2 * time.Hour, 48 * time.Hour},
+			rotateBefore: time.Hour,
+			want:         true,
+		},
+		{
+			name:         "source not newer, destination far from expiry: no update",
+			sourceWindow: [2]time.Duration{-2 * time.Hour, time.Hour},
+			destWindow:   &[2]time.Duration{-time.Hour, 48 * time.Hour},
+			rotateBefore: time.Hour,
+			want:         false,
+		},
+		{
+			name:         "source not newer, destination within rotation window: update",
+			sourceWindow: [2]time.Duration{-2 * time.Hour, time.Hour},
+			destWindow:   &[2]time.Duration{-time.Hour, 30 * time.Minute},
+			rotateBefore: time.Hour,
+			want:         true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			origRotateBefore := rotateBefore
+			rotateBefore = tc.rotateBefore
+			defer func() { rotateBefore = origRotateBefore }()
+
+			sourceCertPEM, sourceKeyPEM := generateTestCert(t, now.Add(tc.sourceWindow[0]), now.Add(tc.sourceWindow[1]), false)
+
+			s := &corev1.Secret{
+				Data: map[string][]byte{
+					certSourceKey: sourceCertPEM,
+					keySourceKey:  sourceKeyPEM,
+				},
+			}
+			if tc.destWindow != nil {
+				destCertPEM, destKeyPEM := generateTestCert(t, now.Add(tc.destWindow[0]), now.Add(tc.destWindow[1]), false)
+				s.Data[certDestKey] = destCertPEM
+				s.Data[keyDestKey] = destKeyPEM
+			}
+
+			got := needsDaprTrustBundleSecretUpdate(logr.Discard(), record.NewFakeRecorder(1), s)
+			if got != tc.want {
+				t.Fatalf("needsDaprTrustBundleSecretUpdate() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}